@@ -0,0 +1,68 @@
+package routes
+
+import (
+    "errors"
+    "mime/multipart"
+    "os"
+
+    "github.com/gofiber/fiber/v2"
+)
+
+// ChartBackend is the storage registry a catalog request is served from.
+// ChartMuseum is the default; ociBackend lets operators point the same
+// frontend-facing API at any Helm-OCI-compatible registry (Harbor, GHCR,
+// ECR, ...) instead.
+type ChartBackend interface {
+    ListCharts() (map[string][]chartMuseumEntry, error)
+    GetVersions(name string) ([]chartMuseumEntry, error)
+    FetchTarball(name, version string) ([]byte, error)
+    Upload(fileHeader *multipart.FileHeader, content []byte) error
+    Delete(name, version string) error
+    UploadProv(fileHeader *multipart.FileHeader, content []byte) error
+}
+
+const (
+    backendNameChartMuseum = "chartmuseum"
+    backendNameOCI         = "oci"
+)
+
+// defaultBackendName is the backend used when a request doesn't specify
+// ?backend=, configured per-deploy via CHART_BACKEND.
+var defaultBackendName = getDefaultBackendName()
+
+func getDefaultBackendName() string {
+    if name := os.Getenv("CHART_BACKEND"); name != "" {
+        return name
+    }
+    return backendNameChartMuseum
+}
+
+// resolveBackend selects the ChartBackend for a request: the `?backend=`
+// query parameter takes precedence over the deploy-wide default.
+func resolveBackend(c *fiber.Ctx) ChartBackend {
+    switch c.Query("backend", defaultBackendName) {
+    case backendNameOCI:
+        return defaultOCIBackend
+    default:
+        return defaultChartMuseumBackend
+    }
+}
+
+// backendStatusError carries the upstream HTTP status code so handlers can
+// relay a meaningful response instead of a blanket 502 on every failure.
+type backendStatusError struct {
+    StatusCode int
+    Message    string
+}
+
+func (e *backendStatusError) Error() string { return e.Message }
+
+// backendErrorStatus extracts the status code from a backendStatusError, or
+// returns fallback if err doesn't carry one.
+func backendErrorStatus(err error, fallback int) int {
+    var statusErr *backendStatusError
+    if errors.As(err, &statusErr) {
+        return statusErr.StatusCode
+    }
+    return fallback
+}
@@ -0,0 +1,264 @@
+package routes
+
+import (
+    "archive/tar"
+    "bytes"
+    "compress/gzip"
+    "container/list"
+    "fmt"
+    "io"
+    "strings"
+    "sync"
+
+    "github.com/gofiber/fiber/v2"
+    "gopkg.in/yaml.v3"
+
+    "github.com/arne-munch-ellingsen/intend-5g4data-workload-catalog/internal/utils"
+)
+
+// chartDetailsCacheSize bounds how many parsed tarballs are kept in memory at once.
+const chartDetailsCacheSize = 64
+
+// maxChartTarballBytes bounds how large a fetched chart tarball (compressed,
+// before decompression) is allowed to be. Backends enforce this while
+// downloading so an oversized or malicious upload can't make every details
+// lookup buffer an unbounded amount of memory.
+const maxChartTarballBytes = 50 * 1024 * 1024 // 50 MiB
+
+// maxChartFileBytes bounds how much of any single decompressed tar entry
+// (README.md, values.yaml, Chart.yaml, requirements.yaml) is read, guarding
+// against a gzip bomb inflating one file far past its compressed size.
+const maxChartFileBytes = 5 * 1024 * 1024 // 5 MiB
+
+// readAllLimited reads at most limit+1 bytes from r and errors out if more
+// than limit bytes were available, rather than silently truncating.
+func readAllLimited(r io.Reader, limit int64) ([]byte, error) {
+    content, err := io.ReadAll(io.LimitReader(r, limit+1))
+    if err != nil {
+        return nil, err
+    }
+    if int64(len(content)) > limit {
+        return nil, fmt.Errorf("exceeds %d byte limit", limit)
+    }
+    return content, nil
+}
+
+// ChartYAML is the subset of Chart.yaml we surface to clients.
+type ChartYAML struct {
+    APIVersion   string            `yaml:"apiVersion" json:"apiVersion"`
+    Name         string            `yaml:"name" json:"name"`
+    Version      string            `yaml:"version" json:"version"`
+    Description  string            `yaml:"description" json:"description"`
+    Type         string            `yaml:"type" json:"type"`
+    AppVersion   string            `yaml:"appVersion" json:"appVersion"`
+    Keywords     []string          `yaml:"keywords" json:"keywords"`
+    Maintainers  []ChartMaintainer `yaml:"maintainers" json:"maintainers"`
+    Dependencies []ChartDependency `yaml:"dependencies" json:"dependencies"`
+}
+
+type ChartMaintainer struct {
+    Name  string `yaml:"name" json:"name"`
+    Email string `yaml:"email" json:"email"`
+}
+
+type ChartDependency struct {
+    Name       string `yaml:"name" json:"name"`
+    Version    string `yaml:"version" json:"version"`
+    Repository string `yaml:"repository" json:"repository"`
+}
+
+// ChartDetails is the parsed, frontend-friendly view of a packaged chart tarball.
+type ChartDetails struct {
+    Chart         ChartYAML         `json:"chart"`
+    ValuesYAML    string            `json:"valuesYaml"`
+    Readme        string            `json:"readme"`
+    TemplateFiles []string          `json:"templateFiles"`
+    Dependencies  []ChartDependency `json:"dependencies"`
+}
+
+// chartDetailsCache is a small in-memory LRU keyed by "name+version+digest" so
+// repeated detail lookups don't re-download and re-parse the tarball.
+type chartDetailsCache struct {
+    mu       sync.Mutex
+    capacity int
+    items    map[string]*list.Element
+    order    *list.List
+}
+
+type chartDetailsCacheEntry struct {
+    key     string
+    details *ChartDetails
+}
+
+func newChartDetailsCache(capacity int) *chartDetailsCache {
+    return &chartDetailsCache{
+        capacity: capacity,
+        items:    make(map[string]*list.Element),
+        order:    list.New(),
+    }
+}
+
+func (c *chartDetailsCache) get(key string) (*ChartDetails, bool) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    elem, ok := c.items[key]
+    if !ok {
+        return nil, false
+    }
+    c.order.MoveToFront(elem)
+    return elem.Value.(*chartDetailsCacheEntry).details, true
+}
+
+func (c *chartDetailsCache) put(key string, details *ChartDetails) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    if elem, ok := c.items[key]; ok {
+        c.order.MoveToFront(elem)
+        elem.Value.(*chartDetailsCacheEntry).details = details
+        return
+    }
+
+    elem := c.order.PushFront(&chartDetailsCacheEntry{key: key, details: details})
+    c.items[key] = elem
+
+    if c.order.Len() > c.capacity {
+        oldest := c.order.Back()
+        if oldest != nil {
+            c.order.Remove(oldest)
+            delete(c.items, oldest.Value.(*chartDetailsCacheEntry).key)
+        }
+    }
+}
+
+var chartDetailsMemo = newChartDetailsCache(chartDetailsCacheSize)
+
+// getChartDetails handles GET /api/charts/:name/:version/details.
+func getChartDetails(c *fiber.Ctx) error {
+    name := c.Params("name")
+    version := c.Params("version")
+    backend := resolveBackend(c)
+
+    digest, err := chartVersionDigest(backend, name, version)
+    if err != nil {
+        return c.Status(backendErrorStatus(err, fiber.StatusBadGateway)).JSON(fiber.Map{
+            "error": "Failed to look up chart metadata",
+        })
+    }
+
+    cacheKey := name + "+" + version + "+" + digest
+    details, ok := chartDetailsMemo.get(cacheKey)
+    if !ok {
+        tarball, err := backend.FetchTarball(name, version)
+        if err != nil {
+            return c.Status(backendErrorStatus(err, fiber.StatusBadGateway)).JSON(fiber.Map{
+                "error": "Failed to download chart tarball",
+            })
+        }
+
+        details, err = parseChartTarball(tarball)
+        if err != nil {
+            return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+                "error": "Failed to parse chart tarball",
+            })
+        }
+
+        chartDetailsMemo.put(cacheKey, details)
+    }
+
+    return utils.SendHAL(c, fiber.StatusOK, fiber.Map{
+        "chart":         details.Chart,
+        "valuesYaml":    details.ValuesYAML,
+        "readme":        details.Readme,
+        "templateFiles": details.TemplateFiles,
+        "dependencies":  details.Dependencies,
+        "_links":        utils.HALChartLinks(name, version),
+    })
+}
+
+// chartVersionDigest looks up the version entry the backend holds for
+// name/version and returns its content digest, used as a cache-busting key.
+func chartVersionDigest(backend ChartBackend, name, version string) (string, error) {
+    versions, err := backend.GetVersions(name)
+    if err != nil {
+        return "", err
+    }
+
+    for _, v := range versions {
+        if v.Version == version {
+            return v.Digest, nil
+        }
+    }
+    return "", fmt.Errorf("version %s not found for chart %s", version, name)
+}
+
+// parseChartTarball extracts Chart.yaml, values.yaml, README.md, template paths
+// and dependency info from a packaged Helm chart tarball.
+func parseChartTarball(data []byte) (*ChartDetails, error) {
+    gz, err := gzip.NewReader(bytes.NewReader(data))
+    if err != nil {
+        return nil, err
+    }
+    defer gz.Close()
+
+    details := &ChartDetails{
+        TemplateFiles: []string{},
+        Dependencies:  []ChartDependency{},
+    }
+
+    tr := tar.NewReader(gz)
+    for {
+        hdr, err := tr.Next()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, err
+        }
+        if hdr.Typeflag != tar.TypeReg {
+            continue
+        }
+
+        // Tarball entries are rooted at "<chart-name>/...".
+        relPath := hdr.Name
+        if idx := strings.Index(relPath, "/"); idx != -1 {
+            relPath = relPath[idx+1:]
+        }
+
+        switch {
+        case relPath == "Chart.yaml":
+            if err := yaml.NewDecoder(io.LimitReader(tr, maxChartFileBytes)).Decode(&details.Chart); err != nil {
+                return nil, err
+            }
+        case relPath == "values.yaml":
+            content, err := readAllLimited(tr, maxChartFileBytes)
+            if err != nil {
+                return nil, fmt.Errorf("values.yaml: %w", err)
+            }
+            details.ValuesYAML = string(content)
+        case strings.EqualFold(relPath, "README.md"):
+            content, err := readAllLimited(tr, maxChartFileBytes)
+            if err != nil {
+                return nil, fmt.Errorf("README.md: %w", err)
+            }
+            details.Readme = string(content)
+        case relPath == "requirements.yaml":
+            var reqs struct {
+                Dependencies []ChartDependency `yaml:"dependencies"`
+            }
+            if err := yaml.NewDecoder(io.LimitReader(tr, maxChartFileBytes)).Decode(&reqs); err != nil {
+                return nil, err
+            }
+            details.Dependencies = reqs.Dependencies
+        case strings.HasPrefix(relPath, "templates/"):
+            details.TemplateFiles = append(details.TemplateFiles, relPath)
+        }
+    }
+
+    if len(details.Dependencies) == 0 && len(details.Chart.Dependencies) > 0 {
+        details.Dependencies = details.Chart.Dependencies
+    }
+
+    return details, nil
+}
@@ -1,16 +1,40 @@
 package routes
 
 import (
-    "bytes"
-    "io"
-    "mime/multipart"
-    "net/http"
-	"os"
+    "encoding/json"
+    "os"
+
     "github.com/gofiber/fiber/v2"
+    "github.com/arne-munch-ellingsen/intend-5g4data-workload-catalog/internal/utils"
 )
 
+// chartMuseumEntry mirrors the per-version object ChartMuseum's API returns.
+type chartMuseumEntry struct {
+    Name        string   `json:"name"`
+    Version     string   `json:"version"`
+    Description string   `json:"description,omitempty"`
+    AppVersion  string   `json:"appVersion,omitempty"`
+    Created     string   `json:"created,omitempty"`
+    Digest      string   `json:"digest,omitempty"`
+    Urls        []string `json:"urls,omitempty"`
+}
+
 var chartMuseumBaseURL = getChartMuseumBaseURL()
 
+// chartCache tracks when each catalog resource (the charts index, and each
+// named chart's version list) was last changed by a POST/DELETE, so GET
+// requests can be short-circuited with 304 Not Modified.
+var chartCache = utils.NewCache()
+
+// chartsIndexCacheKey and chartNameCacheKey live in disjoint namespaces
+// ("charts:list" vs "charts:name:...") so a chart literally named "index"
+// can't collide with the charts-index sentinel.
+const chartsIndexCacheKey = "charts:list"
+
+func chartNameCacheKey(name string) string {
+    return "charts:name:" + name
+}
+
 func getChartMuseumBaseURL() string {
     if url := os.Getenv("CHARTMUSEUM_URL"); url != "" {
         return url
@@ -26,15 +50,18 @@ func RegisterChartMuseumRoutes(app *fiber.App) {
 
     // Future routes:
 	api.Post("/charts", uploadChartToChartMuseum)
-    api.Post("/prov", notImplemented("POST /api/prov"))
+	api.Post("/charts/signed", uploadSignedChart)
+    api.Post("/prov", uploadProv)
 	api.Delete("/charts/:name/:version", deleteChartVersion)
 	api.Get("/charts/:name", getChartVersions)
+	api.Get("/charts/:name/:version/details", getChartDetails)
+	api.Get("/charts/:name/:version/prov", getChartProv)
+    api.Get("/events", getChartEvents)
     api.Head("/charts/:name", notImplemented("HEAD /api/charts/:name"))
     api.Head("/charts/:name/:version", notImplemented("HEAD /api/charts/:name/:version"))
 }
 
 func uploadChartToChartMuseum(c *fiber.Ctx) error {
-    // Get the uploaded file from the form
     fileHeader, err := c.FormFile("chart")
     if err != nil {
         return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -42,47 +69,30 @@ func uploadChartToChartMuseum(c *fiber.Ctx) error {
         })
     }
 
-    file, err := fileHeader.Open()
+    content, err := readFormFile(fileHeader)
     if err != nil {
         return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-            "error": "Failed to open uploaded file",
+            "error": "Failed to read uploaded file",
         })
     }
-    defer file.Close()
-
-    // Create multipart form request to ChartMuseum
-    var b bytes.Buffer
-    writer := multipart.NewWriter(&b)
 
-    formFile, err := writer.CreateFormFile("chart", fileHeader.Filename)
-    if err != nil {
-        return err
-    }
-
-    if _, err := io.Copy(formFile, file); err != nil {
-        return err
+    backend := resolveBackend(c)
+    if err := backend.Upload(fileHeader, content); err != nil {
+        return c.Status(backendErrorStatus(err, fiber.StatusBadGateway)).JSON(fiber.Map{
+            "error": "Failed to upload chart",
+        })
     }
 
-    writer.Close()
-
-    // Send POST to ChartMuseum
-    req, err := http.NewRequest("POST", chartMuseumBaseURL+"/api/charts", &b)
-    if err != nil {
-        return err
+    chartCache.Touch(chartsIndexCacheKey)
+    if details, err := parseChartTarball(content); err == nil {
+        chartCache.Touch(chartNameCacheKey(details.Chart.Name))
+        publishChartEvent(c.Get("X-Request-Source"), "create", details.Chart.Name, details.Chart.Version)
     }
-    req.Header.Set("Content-Type", writer.FormDataContentType())
 
-    client := &http.Client{}
-    resp, err := client.Do(req)
-    if err != nil {
-        return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
-            "error": "Failed to connect to ChartMuseum",
-        })
+    if source := c.Get("X-Request-Source"); source != "" {
+        c.Set("X-Request-Source", source)
     }
-    defer resp.Body.Close()
-
-    body, _ := io.ReadAll(resp.Body)
-    return c.Status(resp.StatusCode).Send(body)
+    return c.SendStatus(fiber.StatusCreated)
 }
 
 func notImplemented(endpoint string) fiber.Handler {
@@ -94,67 +104,114 @@ func notImplemented(endpoint string) fiber.Handler {
 }
 
 func getChartsFromChartMuseum(c *fiber.Ctx) error {
-    // Preserve query parameters
-    fullURL := chartMuseumBaseURL + "/api/charts" + "?" + c.Context().QueryArgs().String()
+    backend := resolveBackend(c)
 
-    // Make GET request to ChartMuseum
-    resp, err := http.Get(fullURL)
+    index, err := backend.ListCharts()
     if err != nil {
-        return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
-            "error": "Failed to connect to ChartMuseum",
+        return c.Status(backendErrorStatus(err, fiber.StatusBadGateway)).JSON(fiber.Map{
+            "error": "Failed to list charts",
         })
     }
-    defer resp.Body.Close()
 
-    // Copy headers and status code
-    c.Set("Content-Type", resp.Header.Get("Content-Type"))
-    c.Status(resp.StatusCode)
-
-    // Copy body to Fiber response
-    body, err := io.ReadAll(resp.Body)
+    body, err := json.Marshal(index)
     if err != nil {
         return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-            "error": "Failed to read response from ChartMuseum",
+            "error": "Failed to encode chart index",
         })
     }
 
-    return c.Send(body)
+    if chartCache.NotModified(c, chartsIndexCacheKey, body) {
+        return c.SendStatus(fiber.StatusNotModified)
+    }
+
+    charts := make([]fiber.Map, 0, len(index))
+    for name, entries := range index {
+        entry := fiber.Map{
+            "name":   name,
+            "_links": utils.HALChartNameLinks(name),
+        }
+        if len(entries) > 0 {
+            latest := entries[0]
+            entry["latestVersion"] = latest.Version
+            entry["description"] = latest.Description
+            entry["appVersion"] = latest.AppVersion
+            entry["created"] = latest.Created
+            entry["digest"] = latest.Digest
+        }
+        charts = append(charts, entry)
+    }
+
+    chartCache.SetCacheHeaders(c, chartsIndexCacheKey, body)
+    return utils.SendHAL(c, fiber.StatusOK, fiber.Map{
+        "_links": utils.HALChartIndexLinks(),
+        "_embedded": fiber.Map{
+            "charts": charts,
+        },
+    })
 }
 
 func deleteChartVersion(c *fiber.Ctx) error {
     name := c.Params("name")
     version := c.Params("version")
+    backend := resolveBackend(c)
 
-    url := chartMuseumBaseURL + "/api/charts/" + name + "/" + version
-
-    req, err := http.NewRequest(http.MethodDelete, url, nil)
-    if err != nil {
-        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create request"})
+    if err := backend.Delete(name, version); err != nil {
+        return c.Status(backendErrorStatus(err, fiber.StatusBadGateway)).JSON(fiber.Map{
+            "error": "Failed to delete chart version",
+        })
     }
 
-    client := &http.Client{}
-    resp, err := client.Do(req)
-    if err != nil {
-        return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": "Failed to reach ChartMuseum"})
-    }
-    defer resp.Body.Close()
+    chartCache.Touch(chartsIndexCacheKey)
+    chartCache.Touch(chartNameCacheKey(name))
+    publishChartEvent(c.Get("X-Request-Source"), "delete", name, version)
 
-    return c.SendStatus(resp.StatusCode)
+    if source := c.Get("X-Request-Source"); source != "" {
+        c.Set("X-Request-Source", source)
+    }
+    return c.SendStatus(fiber.StatusOK)
 }
 
 func getChartVersions(c *fiber.Ctx) error {
     name := c.Params("name")
-    url := chartMuseumBaseURL + "/api/charts/" + name
+    backend := resolveBackend(c)
 
-    resp, err := http.Get(url)
+    versions, err := backend.GetVersions(name)
     if err != nil {
-        return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
-            "error": "Failed to connect to ChartMuseum",
+        return c.Status(backendErrorStatus(err, fiber.StatusBadGateway)).JSON(fiber.Map{
+            "error": "Failed to list chart versions",
+        })
+    }
+
+    body, err := json.Marshal(versions)
+    if err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to encode chart versions",
+        })
+    }
+
+    cacheKey := chartNameCacheKey(name)
+    if chartCache.NotModified(c, cacheKey, body) {
+        return c.SendStatus(fiber.StatusNotModified)
+    }
+
+    embedded := make([]fiber.Map, 0, len(versions))
+    for _, v := range versions {
+        embedded = append(embedded, fiber.Map{
+            "name":        v.Name,
+            "version":     v.Version,
+            "description": v.Description,
+            "appVersion":  v.AppVersion,
+            "created":     v.Created,
+            "digest":      v.Digest,
+            "_links":      utils.HALChartLinks(name, v.Version),
         })
     }
-    defer resp.Body.Close()
 
-    body, _ := io.ReadAll(resp.Body)
-    c.Set("Content-Type", resp.Header.Get("Content-Type"))
-    return c.Status(resp.StatusCode).Send(body)
+    chartCache.SetCacheHeaders(c, cacheKey, body)
+    return utils.SendHAL(c, fiber.StatusOK, fiber.Map{
+        "_links": utils.HALChartNameLinks(name),
+        "_embedded": fiber.Map{
+            "versions": embedded,
+        },
+    })
 }
@@ -0,0 +1,155 @@
+package routes
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "io"
+    "mime/multipart"
+    "net/http"
+)
+
+// chartMuseumBackend implements ChartBackend against a ChartMuseum-compatible
+// HTTP API. It is the default ChartBackend for this deployment.
+type chartMuseumBackend struct {
+    baseURL string
+}
+
+var defaultChartMuseumBackend = &chartMuseumBackend{baseURL: chartMuseumBaseURL}
+
+func (b *chartMuseumBackend) ListCharts() (map[string][]chartMuseumEntry, error) {
+    resp, err := http.Get(b.baseURL + "/api/charts")
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return nil, chartMuseumStatusError(resp, "failed to list charts")
+    }
+
+    var index map[string][]chartMuseumEntry
+    if err := json.NewDecoder(resp.Body).Decode(&index); err != nil {
+        return nil, err
+    }
+    return index, nil
+}
+
+func (b *chartMuseumBackend) GetVersions(name string) ([]chartMuseumEntry, error) {
+    resp, err := http.Get(b.baseURL + "/api/charts/" + name)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return nil, chartMuseumStatusError(resp, "failed to list versions for "+name)
+    }
+
+    var versions []chartMuseumEntry
+    if err := json.NewDecoder(resp.Body).Decode(&versions); err != nil {
+        return nil, err
+    }
+    return versions, nil
+}
+
+func (b *chartMuseumBackend) FetchTarball(name, version string) ([]byte, error) {
+    url := fmt.Sprintf("%s/charts/%s-%s.tgz", b.baseURL, name, version)
+    resp, err := http.Get(url)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return nil, chartMuseumStatusError(resp, "failed to download "+name+"-"+version+".tgz")
+    }
+
+    content, err := readAllLimited(resp.Body, maxChartTarballBytes)
+    if err != nil {
+        return nil, fmt.Errorf("%s-%s.tgz: %w", name, version, err)
+    }
+    return content, nil
+}
+
+func (b *chartMuseumBackend) Upload(fileHeader *multipart.FileHeader, content []byte) error {
+    client := &http.Client{}
+    resp, body, err := postFormFile(client, b.baseURL+"/api/charts", "chart", fileHeader.Filename, content)
+    if err != nil {
+        return err
+    }
+    if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+        return &backendStatusError{StatusCode: resp.StatusCode, Message: "chartmuseum rejected chart upload: " + string(body)}
+    }
+    return nil
+}
+
+func (b *chartMuseumBackend) Delete(name, version string) error {
+    req, err := http.NewRequest(http.MethodDelete, b.baseURL+"/api/charts/"+name+"/"+version, nil)
+    if err != nil {
+        return err
+    }
+
+    client := &http.Client{}
+    resp, err := client.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+        return chartMuseumStatusError(resp, "failed to delete "+name+"/"+version)
+    }
+    return nil
+}
+
+func (b *chartMuseumBackend) UploadProv(fileHeader *multipart.FileHeader, content []byte) error {
+    client := &http.Client{}
+    resp, body, err := postFormFile(client, b.baseURL+"/api/prov", "prov", fileHeader.Filename, content)
+    if err != nil {
+        return err
+    }
+    if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+        return &backendStatusError{StatusCode: resp.StatusCode, Message: "chartmuseum rejected prov upload: " + string(body)}
+    }
+    return nil
+}
+
+func chartMuseumStatusError(resp *http.Response, message string) error {
+    return &backendStatusError{StatusCode: resp.StatusCode, Message: message}
+}
+
+// postFormFile sends content to url as a multipart form field named
+// fieldName, returning the upstream response and its body.
+func postFormFile(client *http.Client, url, fieldName, filename string, content []byte) (*http.Response, []byte, error) {
+    var b bytes.Buffer
+    writer := multipart.NewWriter(&b)
+
+    formFile, err := writer.CreateFormFile(fieldName, filename)
+    if err != nil {
+        return nil, nil, err
+    }
+    if _, err := formFile.Write(content); err != nil {
+        return nil, nil, err
+    }
+    writer.Close()
+
+    req, err := http.NewRequest("POST", url, &b)
+    if err != nil {
+        return nil, nil, err
+    }
+    req.Header.Set("Content-Type", writer.FormDataContentType())
+
+    resp, err := client.Do(req)
+    if err != nil {
+        return nil, nil, err
+    }
+    defer resp.Body.Close()
+
+    body, err := io.ReadAll(resp.Body)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    return resp, body, nil
+}
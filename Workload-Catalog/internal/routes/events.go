@@ -0,0 +1,139 @@
+package routes
+
+import (
+    "bufio"
+    "encoding/json"
+    "fmt"
+    "sync"
+    "time"
+
+    "github.com/gofiber/fiber/v2"
+)
+
+// eventSubscriberBuffer bounds how many unread events a slow SSE client can
+// fall behind before we start dropping them rather than blocking publishers.
+const eventSubscriberBuffer = 16
+
+// eventHeartbeatInterval bounds how long a subscriber can go without any
+// write attempt. Without it, a client that disconnects between chart
+// mutations is never written to again, so its goroutine and channel leak
+// forever instead of being cleaned up on the next failed write.
+const eventHeartbeatInterval = 15 * time.Second
+
+// chartEvent is published whenever a chart mutation succeeds.
+type chartEvent struct {
+    Object string      `json:"object"`
+    Action string      `json:"action"`
+    Data   interface{} `json:"data"`
+    Source string      `json:"source,omitempty"`
+}
+
+type eventSubscriber struct {
+    ch chan chartEvent
+}
+
+// eventHub is an in-process pub/sub hub: one buffered channel per
+// subscriber; an event that a slow consumer's full buffer can't take is
+// dropped for that consumer rather than blocking publish.
+type eventHub struct {
+    mu          sync.Mutex
+    subscribers map[*eventSubscriber]struct{}
+}
+
+func newEventHub() *eventHub {
+    return &eventHub{subscribers: make(map[*eventSubscriber]struct{})}
+}
+
+func (h *eventHub) subscribe() *eventSubscriber {
+    sub := &eventSubscriber{ch: make(chan chartEvent, eventSubscriberBuffer)}
+    h.mu.Lock()
+    h.subscribers[sub] = struct{}{}
+    h.mu.Unlock()
+    return sub
+}
+
+func (h *eventHub) unsubscribe(sub *eventSubscriber) {
+    h.mu.Lock()
+    delete(h.subscribers, sub)
+    h.mu.Unlock()
+    close(sub.ch)
+}
+
+func (h *eventHub) publish(evt chartEvent) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    for sub := range h.subscribers {
+        select {
+        case sub.ch <- evt:
+        default:
+            // Slow consumer: drop the event instead of blocking the publisher.
+        }
+    }
+}
+
+var chartEvents = newEventHub()
+
+// publishChartEvent notifies subscribers of a successful chart mutation.
+// source is the X-Request-Source of the request that caused it, so the
+// originating client can recognize and skip its own events.
+func publishChartEvent(source, action, name, version string) {
+    chartEvents.publish(chartEvent{
+        Object: "chart",
+        Action: action,
+        Data: fiber.Map{
+            "name":    name,
+            "version": version,
+        },
+        Source: source,
+    })
+}
+
+// getChartEvents handles GET /api/events, upgrading the connection to a
+// Server-Sent Events stream of chart repository changes.
+func getChartEvents(c *fiber.Ctx) error {
+    c.Set("Content-Type", "text/event-stream")
+    c.Set("Cache-Control", "no-cache")
+    c.Set("Connection", "keep-alive")
+
+    sub := chartEvents.subscribe()
+    ctx := c.Context()
+
+    ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+        defer chartEvents.unsubscribe(sub)
+
+        ticker := time.NewTicker(eventHeartbeatInterval)
+        defer ticker.Stop()
+
+        for {
+            select {
+            case evt, ok := <-sub.ch:
+                if !ok {
+                    return
+                }
+                payload, err := json.Marshal(evt)
+                if err != nil {
+                    continue
+                }
+                if _, err := fmt.Fprintf(w, "data: %s\n\n", payload); err != nil {
+                    return
+                }
+                if err := w.Flush(); err != nil {
+                    return
+                }
+            case <-ticker.C:
+                // Comment-only SSE ping: forces a write so a dead connection
+                // is detected and cleaned up even with no chart activity.
+                if _, err := fmt.Fprintf(w, ": heartbeat\n\n"); err != nil {
+                    return
+                }
+                if err := w.Flush(); err != nil {
+                    return
+                }
+            case <-ctx.Done():
+                return
+            }
+        }
+    })
+
+    return nil
+}
@@ -0,0 +1,191 @@
+package routes
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "mime/multipart"
+    "os"
+
+    "github.com/gofiber/fiber/v2"
+    ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+    "oras.land/oras-go/v2"
+    "oras.land/oras-go/v2/content/memory"
+    "oras.land/oras-go/v2/registry/remote"
+    "oras.land/oras-go/v2/registry/remote/auth"
+)
+
+// Helm 3's OCI support (https://helm.sh/docs/topics/registries/) packages a
+// chart as a two-layer OCI artifact: a config describing the chart, and a
+// single layer holding the packaged .tgz.
+const (
+    helmChartConfigMediaType  = "application/vnd.cncf.helm.config.v1+json"
+    helmChartContentMediaType = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+)
+
+// ociBackend implements ChartBackend against any registry that speaks the
+// Helm 3 OCI chart spec (Harbor, GHCR, ECR, ...), letting operators migrate
+// off ChartMuseum without changing the frontend-facing API.
+type ociBackend struct {
+    registry string // e.g. "ghcr.io/example-org/charts"
+}
+
+var defaultOCIBackend = &ociBackend{registry: os.Getenv("OCI_REGISTRY")}
+
+func (b *ociBackend) repository(ctx context.Context, name string) (*remote.Repository, error) {
+    repo, err := remote.NewRepository(b.registry + "/" + name)
+    if err != nil {
+        return nil, err
+    }
+
+    if user, pass := os.Getenv("OCI_USERNAME"), os.Getenv("OCI_PASSWORD"); user != "" {
+        repo.Client = &auth.Client{
+            Credential: auth.StaticCredential(repo.Reference.Registry, auth.Credential{
+                Username: user,
+                Password: pass,
+            }),
+        }
+    }
+
+    return repo, nil
+}
+
+// ListCharts has no equivalent in the OCI distribution spec, which only
+// supports listing tags within a single repository (see GetVersions).
+func (b *ociBackend) ListCharts() (map[string][]chartMuseumEntry, error) {
+    return nil, &backendStatusError{
+        StatusCode: fiber.StatusNotImplemented,
+        Message:    "oci backend does not support listing all charts; query a specific chart by name",
+    }
+}
+
+func (b *ociBackend) GetVersions(name string) ([]chartMuseumEntry, error) {
+    ctx := context.Background()
+    repo, err := b.repository(ctx, name)
+    if err != nil {
+        return nil, err
+    }
+
+    var tags []string
+    err = repo.Tags(ctx, "", func(t []string) error {
+        tags = append(tags, t...)
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    // OCI tags are mutable: resolve each tag to its current manifest digest
+    // so chart_details.go's LRU cache key changes when a tag is re-pushed,
+    // instead of serving stale Chart.yaml/values.yaml/README forever.
+    versions := make([]chartMuseumEntry, 0, len(tags))
+    for _, tag := range tags {
+        desc, err := repo.Resolve(ctx, tag)
+        if err != nil {
+            return nil, err
+        }
+        versions = append(versions, chartMuseumEntry{Name: name, Version: tag, Digest: desc.Digest.String()})
+    }
+
+    return versions, nil
+}
+
+func (b *ociBackend) FetchTarball(name, version string) ([]byte, error) {
+    ctx := context.Background()
+    repo, err := b.repository(ctx, name)
+    if err != nil {
+        return nil, err
+    }
+
+    _, manifestBytes, err := oras.FetchBytes(ctx, repo, version, oras.DefaultFetchBytesOptions)
+    if err != nil {
+        return nil, err
+    }
+
+    var manifest ocispec.Manifest
+    if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+        return nil, err
+    }
+
+    for _, layer := range manifest.Layers {
+        if layer.MediaType != helmChartContentMediaType {
+            continue
+        }
+        if layer.Size > maxChartTarballBytes {
+            return nil, fmt.Errorf("chart content layer for %s:%s is %d bytes, exceeds %d byte limit", name, version, layer.Size, maxChartTarballBytes)
+        }
+        rc, err := repo.Fetch(ctx, layer)
+        if err != nil {
+            return nil, err
+        }
+        defer rc.Close()
+        return readAllLimited(rc, maxChartTarballBytes)
+    }
+
+    return nil, fmt.Errorf("no helm chart content layer found for %s:%s", name, version)
+}
+
+func (b *ociBackend) Upload(fileHeader *multipart.FileHeader, content []byte) error {
+    details, err := parseChartTarball(content)
+    if err != nil {
+        return err
+    }
+
+    ctx := context.Background()
+    repo, err := b.repository(ctx, details.Chart.Name)
+    if err != nil {
+        return err
+    }
+
+    memStore := memory.New()
+
+    chartDesc, err := oras.PushBytes(ctx, memStore, helmChartContentMediaType, content)
+    if err != nil {
+        return err
+    }
+
+    configDesc, err := oras.PushBytes(ctx, memStore, helmChartConfigMediaType, []byte("{}"))
+    if err != nil {
+        return err
+    }
+
+    manifestDesc, err := oras.PackManifest(ctx, memStore, oras.PackManifestVersion1_1, helmChartConfigMediaType, oras.PackManifestOptions{
+        ConfigDescriptor: &configDesc,
+        Layers:           []ocispec.Descriptor{chartDesc},
+    })
+    if err != nil {
+        return err
+    }
+
+    if err := memStore.Tag(ctx, manifestDesc, details.Chart.Version); err != nil {
+        return err
+    }
+
+    _, err = oras.Copy(ctx, memStore, details.Chart.Version, repo, details.Chart.Version, oras.DefaultCopyOptions)
+    return err
+}
+
+func (b *ociBackend) Delete(name, version string) error {
+    ctx := context.Background()
+    repo, err := b.repository(ctx, name)
+    if err != nil {
+        return err
+    }
+
+    desc, err := repo.Resolve(ctx, version)
+    if err != nil {
+        return err
+    }
+
+    return repo.Manifests().Delete(ctx, desc)
+}
+
+// UploadProv is not supported: Upload pushes only the chart content layer and
+// an empty config, so charts stored through this backend carry no provenance.
+// POST /api/charts/signed also fails here, rolling back its chart upload.
+func (b *ociBackend) UploadProv(fileHeader *multipart.FileHeader, content []byte) error {
+    return &backendStatusError{
+        StatusCode: fiber.StatusNotImplemented,
+        Message:    "oci backend does not support prov uploads",
+    }
+}
@@ -0,0 +1,115 @@
+package routes
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/hex"
+    "os"
+    "path/filepath"
+    "testing"
+
+    "golang.org/x/crypto/openpgp"
+    "golang.org/x/crypto/openpgp/armor"
+    "golang.org/x/crypto/openpgp/clearsign"
+)
+
+func TestVerifyTarballDigest(t *testing.T) {
+    tarball := []byte("pretend this is a packaged chart tarball")
+    sum := sha256.Sum256(tarball)
+    digest := "sha256:" + hex.EncodeToString(sum[:])
+
+    manifest := []byte("files:\n  demo-1.0.0.tgz: " + digest + "\n")
+
+    if err := verifyTarballDigest(manifest, tarball, "demo", "1.0.0"); err != nil {
+        t.Fatalf("expected matching digest to verify, got error: %v", err)
+    }
+
+    if err := verifyTarballDigest(manifest, []byte("a different tarball"), "demo", "1.0.0"); err == nil {
+        t.Fatal("expected mismatched tarball digest to fail verification")
+    }
+
+    if err := verifyTarballDigest(manifest, tarball, "other-chart", "1.0.0"); err == nil {
+        t.Fatal("expected manifest lookup for a different filename to fail")
+    }
+}
+
+// writeTestKeyring generates a throwaway GPG entity, signs prov with its
+// private key, and writes its armored public key to a file under t.TempDir,
+// returning the keyring path and the clearsigned prov bytes.
+func writeTestKeyring(t *testing.T, prov []byte) (keyringPath string, signed []byte) {
+    t.Helper()
+
+    entity, err := openpgp.NewEntity("Test Signer", "", "signer@example.com", nil)
+    if err != nil {
+        t.Fatalf("failed to generate test key: %v", err)
+    }
+
+    var pubKey bytes.Buffer
+    armorWriter, err := armor.Encode(&pubKey, openpgp.PublicKeyType, nil)
+    if err != nil {
+        t.Fatalf("failed to open armor writer: %v", err)
+    }
+    if err := entity.Serialize(armorWriter); err != nil {
+        t.Fatalf("failed to serialize public key: %v", err)
+    }
+    if err := armorWriter.Close(); err != nil {
+        t.Fatalf("failed to close armor writer: %v", err)
+    }
+
+    keyringPath = filepath.Join(t.TempDir(), "keyring.asc")
+    if err := os.WriteFile(keyringPath, pubKey.Bytes(), 0o600); err != nil {
+        t.Fatalf("failed to write keyring: %v", err)
+    }
+
+    var signedBuf bytes.Buffer
+    plaintext, err := clearsign.Encode(&signedBuf, entity.PrivateKey, nil)
+    if err != nil {
+        t.Fatalf("failed to open clearsign writer: %v", err)
+    }
+    if _, err := plaintext.Write(prov); err != nil {
+        t.Fatalf("failed to write prov body: %v", err)
+    }
+    if err := plaintext.Close(); err != nil {
+        t.Fatalf("failed to close clearsign writer: %v", err)
+    }
+
+    return keyringPath, signedBuf.Bytes()
+}
+
+func TestVerifyProvSignatureVerifiesMatchingDigest(t *testing.T) {
+    tarball := []byte("pretend this is a packaged chart tarball")
+    sum := sha256.Sum256(tarball)
+    manifest := []byte("files:\n  demo-1.0.0.tgz: sha256:" + hex.EncodeToString(sum[:]) + "\n")
+
+    keyringPath, signed := writeTestKeyring(t, manifest)
+    oldPath := chartGPGKeyringPath
+    chartGPGKeyringPath = keyringPath
+    defer func() { chartGPGKeyringPath = oldPath }()
+
+    signer, keyID, err := verifyProvSignature(signed, tarball, "demo", "1.0.0")
+    if err != nil {
+        t.Fatalf("expected signature to verify, got error: %v", err)
+    }
+    if signer != "Test Signer <signer@example.com>" {
+        t.Errorf("unexpected signer: %q", signer)
+    }
+    if keyID == "" {
+        t.Error("expected a non-empty key ID")
+    }
+}
+
+func TestVerifyProvSignatureRejectsTarballSwap(t *testing.T) {
+    tarball := []byte("pretend this is a packaged chart tarball")
+    sum := sha256.Sum256(tarball)
+    manifest := []byte("files:\n  demo-1.0.0.tgz: sha256:" + hex.EncodeToString(sum[:]) + "\n")
+
+    keyringPath, signed := writeTestKeyring(t, manifest)
+    oldPath := chartGPGKeyringPath
+    chartGPGKeyringPath = keyringPath
+    defer func() { chartGPGKeyringPath = oldPath }()
+
+    swapped := []byte("a tarball swapped in after signing")
+    if _, _, err := verifyProvSignature(signed, swapped, "demo", "1.0.0"); err == nil {
+        t.Fatal("expected verification to fail when the served tarball doesn't match the signed manifest")
+    }
+}
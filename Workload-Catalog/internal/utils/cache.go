@@ -0,0 +1,80 @@
+package utils
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "net/http"
+    "sync"
+    "time"
+
+    "github.com/gofiber/fiber/v2"
+)
+
+// Cache tracks a last-modified timestamp per resource key and answers
+// conditional GET requests with 304 Not Modified when the client's cached
+// copy is still current.
+type Cache struct {
+    mu       sync.RWMutex
+    lastEdit map[string]time.Time
+}
+
+func NewCache() *Cache {
+    return &Cache{lastEdit: make(map[string]time.Time)}
+}
+
+// Touch records that the resource at key changed now. Call this after a
+// successful POST/DELETE against that resource.
+func (c *Cache) Touch(key string) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.lastEdit[key] = time.Now()
+}
+
+// LastEdit returns the last recorded modification time for key, or the
+// zero time if no edit has been recorded yet.
+func (c *Cache) LastEdit(key string) time.Time {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    return c.lastEdit[key]
+}
+
+// ETag computes a weak ETag over body, for resources whose modification
+// time isn't (yet) tracked by Touch.
+func ETag(body []byte) string {
+    sum := sha256.Sum256(body)
+    return `W/"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// NotModified checks the request's If-None-Match/If-Modified-Since headers
+// against key's last known edit time and a computed ETag of body. It
+// returns true (and sets the validator headers) if the client's cached
+// copy is still current, so the caller can respond 304 Not Modified.
+func (c *Cache) NotModified(ctx *fiber.Ctx, key string, body []byte) bool {
+    etag := ETag(body)
+    lastEdit := c.LastEdit(key)
+
+    if none := ctx.Get(fiber.HeaderIfNoneMatch); none != "" && none == etag {
+        ctx.Set(fiber.HeaderETag, etag)
+        return true
+    }
+
+    if ims := ctx.Get(fiber.HeaderIfModifiedSince); ims != "" && !lastEdit.IsZero() {
+        // HTTP dates carry whole seconds only, so compare at that precision.
+        if t, err := http.ParseTime(ims); err == nil && !lastEdit.Truncate(time.Second).After(t) {
+            ctx.Set(fiber.HeaderETag, etag)
+            ctx.Set(fiber.HeaderLastModified, lastEdit.UTC().Format(http.TimeFormat))
+            return true
+        }
+    }
+
+    return false
+}
+
+// SetCacheHeaders stamps the response with an ETag over body and, if known,
+// the last recorded edit time for key.
+func (c *Cache) SetCacheHeaders(ctx *fiber.Ctx, key string, body []byte) {
+    ctx.Set(fiber.HeaderETag, ETag(body))
+    if lastEdit := c.LastEdit(key); !lastEdit.IsZero() {
+        ctx.Set(fiber.HeaderLastModified, lastEdit.UTC().Format(http.TimeFormat))
+    }
+}
@@ -0,0 +1,45 @@
+package utils
+
+import "github.com/gofiber/fiber/v2"
+
+// HALContentType is the media type used for hypermedia catalog responses.
+const HALContentType = "application/hal+json"
+
+// SendHAL writes payload as a HAL+JSON response with the given status code.
+// payload is expected to already contain any "_links"/"_embedded" keys.
+func SendHAL(c *fiber.Ctx, status int, payload fiber.Map) error {
+    // c.JSON always stamps application/json, so the HAL media type is set after it.
+    if err := c.Status(status).JSON(payload); err != nil {
+        return err
+    }
+    c.Set("Content-Type", HALContentType)
+    return nil
+}
+
+// HALChartIndexLinks builds the _links block for the charts collection resource.
+func HALChartIndexLinks() fiber.Map {
+    return fiber.Map{
+        "self": fiber.Map{"href": "/api/charts"},
+    }
+}
+
+// HALChartNameLinks builds the _links block for a single chart's version-list resource.
+func HALChartNameLinks(name string) fiber.Map {
+    return fiber.Map{
+        "self":    fiber.Map{"href": "/api/charts/" + name},
+        "charts":  fiber.Map{"href": "/api/charts"},
+        "versions": fiber.Map{"href": "/api/charts/" + name},
+    }
+}
+
+// HALChartLinks builds the _links block for a single chart version resource.
+func HALChartLinks(name, version string) fiber.Map {
+    base := "/api/charts/" + name + "/" + version
+    return fiber.Map{
+        "self":     fiber.Map{"href": base},
+        "versions": fiber.Map{"href": "/api/charts/" + name},
+        "delete":   fiber.Map{"href": base},
+        "prov":     fiber.Map{"href": base + "/prov"},
+        "details":  fiber.Map{"href": base + "/details"},
+    }
+}